@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// backingStore is a fixed-size disk-backed swap file, laid out as:
+//
+//	[master page][slot table][backing blocks...]
+//
+// The master page records the file's shape plus the head of a free-list of
+// backing blocks; free blocks thread that list through their own first 8
+// bytes, so no extra bookkeeping space is needed for it. The slot table maps
+// each virtual page to the backing block holding its last-written contents,
+// -1 if the page has no block (never swapped out, or its block was freed).
+const (
+	backingStoreMagic   uint32 = 0x50535430 // "PST0"
+	backingStoreVersion uint32 = 1
+	masterPageSize      int64  = 4 + 4 + 8 + 8 + 8 + 8 // magic, version, pageSize, numPages, numBackingBlocks, freeListHead
+)
+
+var errBackingStoreFull = errors.New("backing store: no free blocks left")
+
+type backingStore struct {
+	f                *os.File
+	pageSize         int64
+	numPages         int64
+	numBackingBlocks int64
+	freeListHead     int64 // index of the first free block, -1 if none
+	slotTable        []int64
+	slotTableOffset  int64
+	blocksOffset     int64
+}
+
+// newBackingStore opens path, creating and formatting it if it doesn't
+// already exist or is too short to hold the master page and slot table.
+func newBackingStore(path string, pageSize, numPages, numBackingBlocks int64) (*backingStore, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	bs := &backingStore{
+		f:                f,
+		pageSize:         pageSize,
+		numPages:         numPages,
+		numBackingBlocks: numBackingBlocks,
+		slotTableOffset:  masterPageSize,
+		blocksOffset:     masterPageSize + numPages*8,
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if info.Size() < bs.blocksOffset {
+		if err := bs.format(); err != nil {
+			f.Close()
+			return nil, err
+		}
+		return bs, nil
+	}
+	if err := bs.readHeader(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	// the on-disk shape must match what this run is about to read/write at
+	// bs.blockOffset(slot); silently trusting a stale shape would size
+	// buffers from the wrong pageSize and corrupt or misread blocks.
+	if bs.pageSize != pageSize || bs.numPages != numPages || bs.numBackingBlocks != numBackingBlocks {
+		f.Close()
+		return nil, fmt.Errorf("backing store: %v was created with pageSize=%v numPages=%v numBackingBlocks=%v, but this run wants pageSize=%v numPages=%v numBackingBlocks=%v",
+			path, bs.pageSize, bs.numPages, bs.numBackingBlocks, pageSize, numPages, numBackingBlocks)
+	}
+	return bs, nil
+}
+
+// format lays out a fresh master page, slot table, and free list chaining
+// every backing block together.
+func (bs *backingStore) format() error {
+	bs.slotTable = make([]int64, bs.numPages)
+	for i := range bs.slotTable {
+		bs.slotTable[i] = -1
+	}
+	if bs.numBackingBlocks > 0 {
+		bs.freeListHead = 0
+	} else {
+		bs.freeListHead = -1
+	}
+
+	if err := bs.writeHeader(); err != nil {
+		return err
+	}
+	if err := bs.writeSlotTable(); err != nil {
+		return err
+	}
+	var i int64
+	for i = 0; i < bs.numBackingBlocks; i++ {
+		next := i + 1
+		if next == bs.numBackingBlocks {
+			next = -1
+		}
+		if err := bs.writeFreeNext(i, next); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (bs *backingStore) readHeader() error {
+	buf := make([]byte, masterPageSize)
+	if _, err := bs.f.ReadAt(buf, 0); err != nil {
+		return err
+	}
+	magic := binary.LittleEndian.Uint32(buf[0:4])
+	version := binary.LittleEndian.Uint32(buf[4:8])
+	if magic != backingStoreMagic || version != backingStoreVersion {
+		return fmt.Errorf("backing store: bad master page (magic=%x version=%v)", magic, version)
+	}
+	bs.pageSize = int64(binary.LittleEndian.Uint64(buf[8:16]))
+	bs.numPages = int64(binary.LittleEndian.Uint64(buf[16:24]))
+	bs.numBackingBlocks = int64(binary.LittleEndian.Uint64(buf[24:32]))
+	bs.freeListHead = int64(binary.LittleEndian.Uint64(buf[32:40]))
+	bs.slotTableOffset = masterPageSize
+	bs.blocksOffset = masterPageSize + bs.numPages*8
+
+	bs.slotTable = make([]int64, bs.numPages)
+	slotBuf := make([]byte, bs.numPages*8)
+	if _, err := bs.f.ReadAt(slotBuf, bs.slotTableOffset); err != nil {
+		return err
+	}
+	var i int64
+	for i = 0; i < bs.numPages; i++ {
+		bs.slotTable[i] = int64(binary.LittleEndian.Uint64(slotBuf[i*8 : i*8+8]))
+	}
+	return nil
+}
+
+func (bs *backingStore) writeHeader() error {
+	buf := make([]byte, masterPageSize)
+	binary.LittleEndian.PutUint32(buf[0:4], backingStoreMagic)
+	binary.LittleEndian.PutUint32(buf[4:8], backingStoreVersion)
+	binary.LittleEndian.PutUint64(buf[8:16], uint64(bs.pageSize))
+	binary.LittleEndian.PutUint64(buf[16:24], uint64(bs.numPages))
+	binary.LittleEndian.PutUint64(buf[24:32], uint64(bs.numBackingBlocks))
+	binary.LittleEndian.PutUint64(buf[32:40], uint64(bs.freeListHead))
+	_, err := bs.f.WriteAt(buf, 0)
+	return err
+}
+
+func (bs *backingStore) writeSlotTable() error {
+	buf := make([]byte, bs.numPages*8)
+	var i int64
+	for i = 0; i < bs.numPages; i++ {
+		binary.LittleEndian.PutUint64(buf[i*8:i*8+8], uint64(bs.slotTable[i]))
+	}
+	_, err := bs.f.WriteAt(buf, bs.slotTableOffset)
+	return err
+}
+
+func (bs *backingStore) writeSlotEntry(pageNum, slot int64) error {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, uint64(slot))
+	_, err := bs.f.WriteAt(buf, bs.slotTableOffset+pageNum*8)
+	return err
+}
+
+func (bs *backingStore) blockOffset(slot int64) int64 {
+	return bs.blocksOffset + slot*bs.pageSize
+}
+
+func (bs *backingStore) readFreeNext(slot int64) (int64, error) {
+	buf := make([]byte, 8)
+	if _, err := bs.f.ReadAt(buf, bs.blockOffset(slot)); err != nil {
+		return 0, err
+	}
+	return int64(binary.LittleEndian.Uint64(buf)), nil
+}
+
+func (bs *backingStore) writeFreeNext(slot, next int64) error {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, uint64(next))
+	_, err := bs.f.WriteAt(buf, bs.blockOffset(slot))
+	return err
+}
+
+func (bs *backingStore) allocBlock() (int64, error) {
+	if bs.freeListHead == -1 {
+		return -1, errBackingStoreFull
+	}
+	slot := bs.freeListHead
+	next, err := bs.readFreeNext(slot)
+	if err != nil {
+		return -1, err
+	}
+	bs.freeListHead = next
+	if err := bs.writeHeader(); err != nil {
+		return -1, err
+	}
+	return slot, nil
+}
+
+func (bs *backingStore) freeBlock(slot int64) error {
+	if err := bs.writeFreeNext(slot, bs.freeListHead); err != nil {
+		return err
+	}
+	bs.freeListHead = slot
+	return bs.writeHeader()
+}
+
+// Write persists buf as pageNum's contents, reusing its existing block if one
+// is already allocated.
+func (bs *backingStore) Write(pageNum int64, buf []byte) error {
+	slot := bs.slotTable[pageNum]
+	if slot == -1 {
+		var err error
+		slot, err = bs.allocBlock()
+		if err != nil {
+			return err
+		}
+		bs.slotTable[pageNum] = slot
+		if err := bs.writeSlotEntry(pageNum, slot); err != nil {
+			return err
+		}
+	}
+	_, err := bs.f.WriteAt(buf, bs.blockOffset(slot))
+	return err
+}
+
+// Read fetches pageNum's last-written contents into buf.
+func (bs *backingStore) Read(pageNum int64, buf []byte) error {
+	slot := bs.slotTable[pageNum]
+	if slot == -1 {
+		return fmt.Errorf("backing store: page %v has no stored contents", pageNum)
+	}
+	_, err := bs.f.ReadAt(buf, bs.blockOffset(slot))
+	return err
+}
+
+// SlotFor returns the backing block index currently holding pageNum, or -1
+// if it has never been written or its block has been freed.
+func (bs *backingStore) SlotFor(pageNum int64) int64 {
+	return bs.slotTable[pageNum]
+}
+
+// Free returns pageNum's block to the free list.
+func (bs *backingStore) Free(pageNum int64) error {
+	slot := bs.slotTable[pageNum]
+	if slot == -1 {
+		return nil
+	}
+	bs.slotTable[pageNum] = -1
+	if err := bs.writeSlotEntry(pageNum, -1); err != nil {
+		return err
+	}
+	return bs.freeBlock(slot)
+}
+
+func (bs *backingStore) Close() error {
+	return bs.f.Close()
+}
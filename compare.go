@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// traceOp is one parsed access operation from a trace file, kept as a flat
+// slice (rather than OPTIMAL.Init()'s OpEntry linked list) so the same trace
+// can be replayed against several policies without re-reading the file.
+type traceOp struct {
+	pageNum int64
+	offset  int64
+	opType  int // 0 for read, 1 for write
+}
+
+// policyMetrics summarizes one policy's run over a trace for the -policies=
+// comparison report.
+type policyMetrics struct {
+	Policy      string  `json:"policy"`
+	References  int64   `json:"references"`
+	Faults      int64   `json:"faults"`
+	Stolen      int64   `json:"stolen"`
+	SwapOuts    int64   `json:"swap_outs"`
+	SwapIns     int64   `json:"swap_ins"`
+	AvgResident float64 `json:"avg_resident_time"`
+}
+
+// newMMU builds the named policy's MMU, also returning its canonical name for
+// logging/reporting. Shared by main()'s single-policy path and the -policies=
+// comparison harness so the two never drift apart. An unrecognized name is an
+// error rather than a silent fallback to OPTIMAL, so a typo can't masquerade
+// as a real comparison result.
+func newMMU(name string, agingTick int64, tau int64) (MMU, string, error) {
+	switch name {
+	case "FIFO":
+		return &FIFO{nxt_idx: make(chan int64, numFrames)}, "FIFO", nil
+	case "LRU":
+		return &LRU{head: nil, tail: nil, size: 0}, "LRU", nil
+	case "CLOCK":
+		return &Clock{}, "CLOCK", nil
+	case "AGING":
+		return &Aging{tickPeriod: agingTick}, "AGING", nil
+	case "WSCLOCK":
+		return &WSClock{tau: tau}, "WSCLOCK", nil
+	case "OPTIMAL":
+		return &OPTIMAL{}, "OPTIMAL", nil
+	default:
+		return nil, "", fmt.Errorf("unrecognized policy %q (want one of FIFO, LRU, CLOCK, AGING, WSCLOCK, OPTIMAL)", name)
+	}
+}
+
+// freshPageMetadata builds an empty page table and frame table, matching
+// main()'s single-policy setup, so each policy in the comparison harness
+// starts from the same blank slate.
+func freshPageMetadata() page_metadata {
+	pageTable := make([]*page, numPages)
+	var i int64
+	for i = 0; i < numPages; i++ {
+		pageTable[i] = &page{pfn: -2, dirty: false, swapSlot: -1}
+	}
+	pageFrames := make([]int64, numFrames)
+	for i = 0; i < numFrames; i++ {
+		pageFrames[i] = -1
+	}
+	return page_metadata{pageTable: pageTable, pageFrames: pageFrames}
+}
+
+// parseTrace reads every remaining operation from r into a flat slice,
+// mirroring OPTIMAL.Init()'s own parsing loop.
+func parseTrace(r *bufio.Reader) ([]traceOp, error) {
+	var ops []traceOp
+	for {
+		inp, err := r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		ss := strings.Split(inp, " ")
+		ss[0] = strings.TrimRight(ss[0], "\n")
+		switch ss[0] {
+		case "#", "debug", "nodprintebug", "print":
+			// these directives only make sense for the interactive
+			// single-run path (they toggle the global debug flag or print
+			// straight to stdout); the comparison harness replays a pure
+			// read/write trace, so drop them here rather than route them
+			// through runPolicyOverOps and corrupt -format=csv/json output.
+			continue
+		default:
+			pageNum, offset, err := convertVirtualAddr(strings.TrimRight(ss[1], "\n"))
+			if err != nil {
+				return nil, err
+			}
+			opType := 0
+			if ss[0] == "w" {
+				opType = 1
+			}
+			ops = append(ops, traceOp{pageNum: pageNum, offset: offset, opType: opType})
+		}
+	}
+	return ops, nil
+}
+
+// buildRefLists builds OPTIMAL's per-page reference-time lists from an
+// in-memory op slice, mirroring the refList construction inline in
+// OPTIMAL.Init()'s own file-parsing loop.
+func buildRefLists(ops []traceOp) []*RefList {
+	refList := make([]*RefList, numPages)
+	var t int64 = 1
+	for _, op := range ops {
+		if op.opType != 0 && op.opType != 1 {
+			continue
+		}
+		entry := &RefListEntry{timeRef: t}
+		if refList[op.pageNum] == nil {
+			refList[op.pageNum] = &RefList{head: entry, tail: entry}
+		} else {
+			refList[op.pageNum].tail.nxt = entry
+			refList[op.pageNum].tail = entry
+		}
+		t++
+	}
+	return refList
+}
+
+// runPolicyOverOps drives one named policy over ops against a fresh
+// page_metadata, reusing OPTIMAL's refList/heap preprocessing instead of
+// re-reading the trace file.
+func runPolicyOverOps(name string, ops []traceOp, agingTick int64, tau int64) (policyMetrics, error) {
+	data = freshPageMetadata()
+	cnt = 1
+	debug = false
+	// the compressed tier is keyed by page number and carries no notion of
+	// which policy put an entry there, so a prior policy's leftover entries
+	// would otherwise be "found" by this run even though its own fresh page
+	// table never swapped them out.
+	if frontswapTier != nil {
+		frontswapTier = newFrontswap(frontswapTier.budget)
+	}
+
+	policy, label, err := newMMU(name, agingTick, tau)
+	if err != nil {
+		return policyMetrics{}, err
+	}
+	if opt, ok := policy.(*OPTIMAL); ok {
+		opt.refList = buildRefLists(ops)
+		opt.initHeap()
+	} else {
+		policy.Init()
+	}
+
+	for _, op := range ops {
+		policy.Access(op.pageNum, op.offset, op.opType == 1)
+		cnt++
+	}
+
+	return collectMetrics(label), nil
+}
+
+// collectMetrics reads the counters page_metadata accumulated during a run,
+// plus the average residency (lastUsed-firstUsed) of every page that was
+// ever mapped, into one policyMetrics row.
+func collectMetrics(name string) policyMetrics {
+	var totalResident, countResident int64
+	var i int64
+	for i = 0; i < numPages; i++ {
+		p := data.pageTable[i]
+		if p.pfn == -2 {
+			continue
+		}
+		totalResident += p.lastUsed - p.firstUsed
+		countResident++
+	}
+	var avgResident float64
+	if countResident > 0 {
+		avgResident = float64(totalResident) / float64(countResident)
+	}
+	return policyMetrics{
+		Policy:      name,
+		References:  data.numReferenced,
+		Faults:      data.numMissed,
+		Stolen:      data.numStolen,
+		SwapOuts:    data.numWrittenToSwap,
+		SwapIns:     data.numRecoveredFromSwapped,
+		AvgResident: avgResident,
+	}
+}
+
+// printReport dispatches to the requested -format= renderer, defaulting to
+// the human-readable table.
+func printReport(results []policyMetrics, format string) {
+	switch format {
+	case "csv":
+		printReportCSV(results)
+	case "json":
+		printReportJSON(results)
+	default:
+		printReportTable(results)
+	}
+}
+
+func printReportTable(results []policyMetrics) {
+	fmt.Printf("%-10s %12s %10s %10s %10s %10s %14s\n",
+		"Policy", "References", "Faults", "Stolen", "SwapOuts", "SwapIns", "AvgResident")
+	for _, r := range results {
+		fmt.Printf("%-10s %12d %10d %10d %10d %10d %14.2f\n",
+			r.Policy, r.References, r.Faults, r.Stolen, r.SwapOuts, r.SwapIns, r.AvgResident)
+	}
+}
+
+func printReportCSV(results []policyMetrics) {
+	fmt.Println("policy,references,faults,stolen,swap_outs,swap_ins,avg_resident_time")
+	for _, r := range results {
+		fmt.Printf("%v,%v,%v,%v,%v,%v,%.2f\n",
+			r.Policy, r.References, r.Faults, r.Stolen, r.SwapOuts, r.SwapIns, r.AvgResident)
+	}
+}
+
+func printReportJSON(results []policyMetrics) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(results); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
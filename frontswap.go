@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+// frontswapEntry is a node in the compressed-page LRU list, mirroring the
+// LRU/LRUEntry pattern used for frame replacement in main.go.
+type frontswapEntry struct {
+	pageNum int64
+	data    []byte // gzip-compressed page contents
+	nxt     *frontswapEntry
+	prev    *frontswapEntry
+}
+
+// frontswap is a bounded, compressed in-memory swap tier that sits in front
+// of the real backing store, mirroring the Linux frontswap/zswap design.
+// When a backing store is configured, entries are evicted least-recently-used
+// down to it once the byte budget is exceeded; without one, nothing is
+// evicted and inserts that don't fit are refused.
+type frontswap struct {
+	budget    int64 // byte budget for compressed data
+	used      int64
+	head      *frontswapEntry
+	tail      *frontswapEntry
+	nodeTable []*frontswapEntry // given a page number, return its frontswapEntry pointer
+}
+
+func newFrontswap(budgetBytes int64) *frontswap {
+	return &frontswap{
+		budget:    budgetBytes,
+		nodeTable: make([]*frontswapEntry, numPages),
+	}
+}
+
+func gzipCompress(buf []byte) []byte {
+	var b bytes.Buffer
+	w := gzip.NewWriter(&b)
+	w.Write(buf)
+	w.Close()
+	return b.Bytes()
+}
+
+func gzipDecompress(compressed []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func (fs *frontswap) unlink(entry *frontswapEntry) {
+	if entry.prev != nil {
+		entry.prev.nxt = entry.nxt
+	} else {
+		fs.head = entry.nxt
+	}
+	if entry.nxt != nil {
+		entry.nxt.prev = entry.prev
+	} else {
+		fs.tail = entry.prev
+	}
+	entry.prev = nil
+	entry.nxt = nil
+}
+
+func (fs *frontswap) pushFront(entry *frontswapEntry) {
+	entry.prev = nil
+	entry.nxt = fs.head
+	if fs.head != nil {
+		fs.head.prev = entry
+	}
+	fs.head = entry
+	if fs.tail == nil {
+		fs.tail = entry
+	}
+}
+
+// evictOldest pops the least-recently-used entry, decompresses it, and
+// returns its page number and raw contents so the caller can write it
+// through to the real backing store.
+func (fs *frontswap) evictOldest() (int64, []byte, bool) {
+	if fs.tail == nil {
+		return 0, nil, false
+	}
+	entry := fs.tail
+	fs.unlink(entry)
+	fs.nodeTable[entry.pageNum] = nil
+	fs.used -= int64(len(entry.data))
+
+	buf, err := gzipDecompress(entry.data)
+	if err != nil {
+		return entry.pageNum, nil, false
+	}
+	return entry.pageNum, buf, true
+}
+
+// Put compresses and stores buf for pageNum, evicting LRU entries to make
+// room under the byte budget first. It returns false if buf doesn't fit:
+// either it exceeds the budget even with the tier empty, or there is no
+// backing store to evict into and the current entries leave too little
+// room. The caller should then fall back to the real backing store itself.
+func (fs *frontswap) Put(pageNum int64, buf []byte) bool {
+	if existing := fs.nodeTable[pageNum]; existing != nil {
+		fs.unlink(existing)
+		fs.nodeTable[pageNum] = nil
+		fs.used -= int64(len(existing.data))
+	}
+
+	compressed := gzipCompress(buf)
+	if int64(len(compressed)) > fs.budget {
+		return false
+	}
+
+	// Only evict to make room when there's a backing store to spill the
+	// evicted entry's contents to — otherwise eviction would silently
+	// destroy data with no way to recover it later, while the page table
+	// still claims it was written to swap. Without a backing store, refuse
+	// the insert instead; the caller (writeBack) falls back to its
+	// counter-only bookkeeping, same as when frontswap isn't configured.
+	for backing != nil && fs.used+int64(len(compressed)) > fs.budget && fs.tail != nil {
+		evictPageNum, evictBuf, ok := fs.evictOldest()
+		if ok {
+			if err := backing.Write(evictPageNum, evictBuf); err == nil {
+				data.pageTable[evictPageNum].swapSlot = backing.SlotFor(evictPageNum)
+			}
+		}
+	}
+	if fs.used+int64(len(compressed)) > fs.budget {
+		return false
+	}
+
+	entry := &frontswapEntry{pageNum: pageNum, data: compressed}
+	fs.nodeTable[pageNum] = entry
+	fs.pushFront(entry)
+	fs.used += int64(len(compressed))
+	return true
+}
+
+// Get fetches and decompresses pageNum's contents if still resident in the
+// tier, refreshing its recency.
+func (fs *frontswap) Get(pageNum int64) ([]byte, bool) {
+	entry := fs.nodeTable[pageNum]
+	if entry == nil {
+		return nil, false
+	}
+	buf, err := gzipDecompress(entry.data)
+	if err != nil {
+		return nil, false
+	}
+	if entry != fs.head {
+		fs.unlink(entry)
+		fs.pushFront(entry)
+	}
+	return buf, true
+}
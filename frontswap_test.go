@@ -0,0 +1,108 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFrontswapPutGetRoundTrip(t *testing.T) {
+	numPages = 4
+	backing = nil
+
+	fs := newFrontswap(1 << 20) // plenty of budget
+	buf := make([]byte, 64)
+	pagePattern(3, buf)
+
+	if !fs.Put(3, buf) {
+		t.Fatalf("Put(3) = false, want true")
+	}
+	got, ok := fs.Get(3)
+	if !ok {
+		t.Fatalf("Get(3) = false after Put, want true")
+	}
+	if !verifyPagePattern(3, got) {
+		t.Fatalf("Get(3) returned wrong contents")
+	}
+}
+
+// TestFrontswapEvictsLRU confirms a budget too small for every entry evicts
+// the least-recently-touched one first, not an arbitrary one.
+func TestFrontswapEvictsLRU(t *testing.T) {
+	numPages = 4
+	numFrames = 1
+	pageSize = 256
+	data = freshPageMetadata()
+
+	// eviction only spills into the backing store (see
+	// TestFrontswapRefusesEvictionWithoutBackingStore for the no-backing
+	// case), so give it one to evict into here.
+	var err error
+	backing, err = newBackingStore(filepath.Join(t.TempDir(), "swap.bin"), pageSize, numPages, numPages)
+	if err != nil {
+		t.Fatalf("newBackingStore: %v", err)
+	}
+	defer backing.Close()
+	defer func() { backing = nil }()
+
+	bufA := make([]byte, 256)
+	pagePattern(0, bufA)
+	entrySize := int64(len(gzipCompress(bufA)))
+
+	fs := newFrontswap(entrySize + entrySize/2) // room for ~1.5 entries
+	if !fs.Put(0, bufA) {
+		t.Fatalf("Put(0) = false, want true")
+	}
+	bufB := make([]byte, 256)
+	pagePattern(1, bufB)
+	if !fs.Put(1, bufB) {
+		t.Fatalf("Put(1) = false, want true")
+	}
+
+	// page 0 hasn't been touched since, so it's the LRU victim evicted to
+	// make room for page 1, not page 1 itself.
+	if _, ok := fs.Get(0); ok {
+		t.Fatalf("Get(0) = true, want false (should have been evicted as LRU)")
+	}
+	got, ok := fs.Get(1)
+	if !ok {
+		t.Fatalf("Get(1) = false, want true (should still be resident)")
+	}
+	if !verifyPagePattern(1, got) {
+		t.Fatalf("Get(1) returned wrong contents")
+	}
+}
+
+// TestFrontswapRefusesEvictionWithoutBackingStore guards the fix for
+// silently discarding evicted entries when there's no backing store to
+// spill them to: Put must refuse the new entry rather than evict the
+// resident one into the void.
+func TestFrontswapRefusesEvictionWithoutBackingStore(t *testing.T) {
+	numPages = 4
+	backing = nil
+
+	bufA := make([]byte, 256)
+	pagePattern(1, bufA)
+	entrySize := int64(len(gzipCompress(bufA)))
+
+	fs := newFrontswap(entrySize) // room for exactly one entry
+	if !fs.Put(1, bufA) {
+		t.Fatalf("Put(1) = false, want true (fits exactly)")
+	}
+
+	bufB := make([]byte, 256)
+	pagePattern(2, bufB)
+	if fs.Put(2, bufB) {
+		t.Fatalf("Put(2) = true, want false (no backing store to spill page 1 to)")
+	}
+
+	got, ok := fs.Get(1)
+	if !ok {
+		t.Fatalf("Get(1) = false, want true (must not have been evicted with nowhere to go)")
+	}
+	if !verifyPagePattern(1, got) {
+		t.Fatalf("Get(1) returned wrong contents")
+	}
+	if _, ok := fs.Get(2); ok {
+		t.Fatalf("Get(2) = true, want false (insert should have been refused)")
+	}
+}
@@ -28,9 +28,8 @@ type MMU interface {
 // struct for a virtual page
 type page struct {
 	pfn       int64 // the corresponding physical frame number. -2 if never mapped, -1 if mapped and stolen.
-	inSwap    bool
 	dirty     bool
-	onDisk    bool // only true if this page has been written to in the past
+	swapSlot  int64 // backing-store block index holding this page's last evicted contents (0 as a marker when no backing store is configured), -1 if none
 	lastUsed  int64
 	firstUsed int64
 }
@@ -44,6 +43,8 @@ type page_metadata struct {
 	numStolen               int64
 	numWrittenToSwap        int64
 	numRecoveredFromSwapped int64
+	numFrontswapStored      int64
+	numFrontswapHits        int64
 }
 
 type FIFO struct {
@@ -84,20 +85,162 @@ type OpEntry struct {
 	nxt     *OpEntry
 }
 
-type OPTIMAL struct {
-	refList          []*RefList // nxtRef[i] = linked list of reference times for page i
-	head             *OpEntry   // head of linked list of page operations to perform
-	tail             *OpEntry
-	size             int64
-	maxFrameIndex    int64 // frame index which has the page that will be used the furthest in the future out of all the current frames
-	maxFrameTime     int64 // next use time of page at the frame maxFrameIndex
-	nxtMaxFrameIndex int64 // for second furthest, similar to maxFrameIndex
-	nxtMaxFrameTime  int64 // for second furthest, similar to maxFrameIndex
+// Clock implements the classic second-chance page replacement algorithm:
+// frames sit in a circular buffer with a hand pointer, and a frame is only
+// evicted once its reference bit has been found clear.
+type Clock struct {
+	ref  []bool // ref[i] = reference bit of frame i
+	hand int64  // next frame the hand will inspect
+	size int64
+}
+
+// Aging approximates LRU by keeping an 8-bit shift-register counter per
+// resident frame. Every tickPeriod references, every counter is shifted
+// right and ORed with its frame's reference bit in the high bit, so frames
+// untouched for longer end up with smaller counters.
+type Aging struct {
+	counter       []uint8
+	refBit        []bool
+	refsSinceTick int64
+	tickPeriod    int64 // how many references between ticks, configurable via CLI
+	size          int64
+}
+
+// WSClock implements the Carr-Hennessy working-set clock algorithm: frames
+// sit in a circular buffer, and a frame is only a victim once it has gone
+// unreferenced for longer than the working-set window tau. Dirty pages past
+// the window are scheduled for swap-out while the sweep continues looking
+// for a clean victim, falling back to the oldest scheduled dirty page.
+type WSClock struct {
+	ref      []bool  // ref[i] = reference bit of frame i
+	lastUsed []int64 // lastUsed[i] = last time frame i's reference bit was seen set
+	hand     int64
+	size     int64
+	tau      int64 // working-set window, configurable via CLI
+}
+
+// heapEntry is a node of OPTIMAL's indexed max-heap, ordered by next-use
+// time with ties broken toward the smaller frame index.
+type heapEntry struct {
+	time       int64
+	frameIndex int64
+}
 
+type OPTIMAL struct {
+	refList []*RefList // refList[i] = linked list of reference times for page i
+	head    *OpEntry   // head of linked list of page operations to perform
+	tail    *OpEntry
+	size    int64
+
+	// indexed max-heap over (next-use time, frame index) for resident
+	// frames; the root is always the correct eviction victim.
+	heap     []heapEntry
+	heapPos  []int64 // heapPos[frameIndex] = that frame's index within heap, -1 if not resident
+	heapSize int64
 }
 
 var data page_metadata // holds page table, page frames, and other metadata
 var cnt int64 = 1
+var backing *backingStore   // disk-backed swap, nil unless -store=path is given
+var frontswapTier *frontswap // compressed in-memory swap tier, nil unless -frontswap=MB is given
+
+// pagePattern deterministically derives pageNum's expected on-disk contents.
+// The simulator doesn't model real page bytes, but writing and later
+// verifying a page-identifying pattern is what makes the backing
+// store/frontswap tier an actual persistence layer rather than an
+// indistinguishable zero buffer round-tripping through it.
+func pagePattern(pageNum int64, buf []byte) {
+	for i := range buf {
+		buf[i] = byte(pageNum + int64(i))
+	}
+}
+
+// verifyPagePattern reports whether buf holds pageNum's expected pattern.
+func verifyPagePattern(pageNum int64, buf []byte) bool {
+	for i := range buf {
+		if buf[i] != byte(pageNum+int64(i)) {
+			return false
+		}
+	}
+	return true
+}
+
+// writeBack persists pageNum's current contents to the frontswap tier if it
+// accepts them, else to the backing store (or, if none is configured, just
+// marks the page as swapped and tallies the counters as before), and clears
+// dirty.
+func writeBack(pageNum int64) {
+	p := data.pageTable[pageNum]
+	if !p.dirty {
+		return
+	}
+	p.dirty = false
+	buf := make([]byte, pageSize)
+	pagePattern(pageNum, buf)
+	if frontswapTier != nil && frontswapTier.Put(pageNum, buf) {
+		data.numFrontswapStored++
+	} else if backing != nil {
+		if err := backing.Write(pageNum, buf); err != nil {
+			fmt.Fprintf(os.Stderr, "backing store write failed for page %v: %v\n", pageNum, err)
+		} else {
+			p.swapSlot = backing.SlotFor(pageNum)
+		}
+	} else {
+		p.swapSlot = 0
+	}
+	data.numWrittenToSwap++
+}
+
+// evictPage writes back pageNum if dirty and marks it stolen from its frame.
+func evictPage(pageNum int64) {
+	writeBack(pageNum)
+	data.pageTable[pageNum].pfn = -1
+	data.numStolen++
+}
+
+// invalidateSwap drops pageNum's backing-store contents, freeing the slot
+// back to the allocator. Used when a page is overwritten right after a fault,
+// since its on-disk copy is now stale.
+func invalidateSwap(pageNum int64) {
+	p := data.pageTable[pageNum]
+	if p.swapSlot >= 0 {
+		if backing != nil {
+			backing.Free(pageNum)
+		}
+		p.swapSlot = -1
+	}
+}
+
+// loadPage accounts for pageNum being faulted back in, where pfn is its frame
+// number as it was *before* this fault (so -2 means never mapped). If the
+// page had been written to the backing store, it's fetched back.
+func loadPage(pageNum int64, pfn int64) {
+	if pfn == -2 { // page to write has never been mapped before
+		data.numMapped++
+		return
+	}
+	if frontswapTier != nil {
+		if buf, ok := frontswapTier.Get(pageNum); ok {
+			if !verifyPagePattern(pageNum, buf) {
+				fmt.Fprintf(os.Stderr, "frontswap: corrupted contents for page %v\n", pageNum)
+			}
+			data.numFrontswapHits++
+			data.numRecoveredFromSwapped++
+			return
+		}
+	}
+	if data.pageTable[pageNum].swapSlot >= 0 {
+		data.numRecoveredFromSwapped++
+		if backing != nil {
+			buf := make([]byte, pageSize)
+			if err := backing.Read(pageNum, buf); err != nil {
+				fmt.Fprintf(os.Stderr, "backing store read failed for page %v: %v\n", pageNum, err)
+			} else if !verifyPagePattern(pageNum, buf) {
+				fmt.Fprintf(os.Stderr, "backing store: corrupted contents for page %v\n", pageNum)
+			}
+		}
+	}
+}
 
 func (mmu *FIFO) Init() {
 
@@ -113,32 +256,16 @@ func (mmu *FIFO) Access(pageNum int64, offset int64, isWrite bool) {
 		if int64(len(mmu.nxt_idx)) == numFrames {
 			frameIndex = <-mmu.nxt_idx
 			replace_pageNum := data.pageFrames[frameIndex] // virtual page number to be replaced
-			if data.pageTable[replace_pageNum].dirty {     // if the page to be replaced is dirty, write it to swap
-				data.pageTable[replace_pageNum].dirty = false
-				data.pageTable[replace_pageNum].onDisk = true
-				data.numWrittenToSwap++
-			}
-			data.pageTable[replace_pageNum].inSwap = true
-			data.pageTable[replace_pageNum].pfn = -1
-			data.numStolen++
+			evictPage(replace_pageNum)
 		} else { // if len(mmu.nxt_idx) < numFrames, we still have space in the frame, use it instead of stealing pages
 			frameIndex = int64(len(mmu.nxt_idx))
 		}
 
-		if pfn == -2 { // page to write has never been mapped before
-			data.numMapped++
-		} else {
-			if data.pageTable[pageNum].inSwap { // check if it's in swap
-				if data.pageTable[pageNum].onDisk { // only true if this page has been written to in the past
-					data.numRecoveredFromSwapped++
-				}
-				data.pageTable[pageNum].inSwap = false
-			}
-		}
+		loadPage(pageNum, pfn)
 		data.pageTable[pageNum].pfn = frameIndex
 		if isWrite {
 			data.pageTable[pageNum].dirty = true
-			data.pageTable[pageNum].onDisk = false
+			invalidateSwap(pageNum)
 		}
 		data.pageTable[pageNum].firstUsed = cnt
 		data.pageFrames[frameIndex] = pageNum
@@ -153,6 +280,239 @@ func (mmu *FIFO) Access(pageNum int64, offset int64, isWrite bool) {
 	data.numReferenced++
 }
 
+func (mmu *Clock) Init() {
+	mmu.ref = make([]bool, numFrames)
+	mmu.hand = 0
+	mmu.size = 0
+}
+
+func (mmu *Clock) Access(pageNum int64, offset int64, isWrite bool) {
+	var frameIndex int64
+
+	pfn := data.pageTable[pageNum].pfn
+	if pfn < 0 { // page is currently not in a frame, put it there
+
+		// frame is full, steal a page.
+		if mmu.size == numFrames {
+			// advance the hand, clearing reference bits, until we find a frame with ref=0
+			for mmu.ref[mmu.hand] {
+				mmu.ref[mmu.hand] = false
+				mmu.hand = (mmu.hand + 1) % numFrames
+			}
+			frameIndex = mmu.hand
+			mmu.hand = (mmu.hand + 1) % numFrames
+
+			replace_pageNum := data.pageFrames[frameIndex] // virtual page number to be replaced
+			evictPage(replace_pageNum)
+		} else { // mmu.size < numFrames, we still have space in the frame, use it instead of stealing pages
+			frameIndex = mmu.size
+			mmu.size++
+		}
+
+		loadPage(pageNum, pfn)
+
+		data.pageTable[pageNum].pfn = frameIndex
+		data.pageTable[pageNum].firstUsed = cnt
+		if isWrite {
+			data.pageTable[pageNum].dirty = true
+			invalidateSwap(pageNum)
+		}
+
+		data.pageFrames[frameIndex] = pageNum
+		mmu.ref[frameIndex] = true
+		data.numMissed++
+	} else { // page is currently in a frame, just set its reference bit
+		if isWrite {
+			data.pageTable[pageNum].dirty = true
+		}
+		mmu.ref[pfn] = true
+	}
+	data.pageTable[pageNum].lastUsed = cnt
+	data.numReferenced++
+}
+
+func (mmu *Aging) Init() {
+	mmu.counter = make([]uint8, numFrames)
+	mmu.refBit = make([]bool, numFrames)
+	mmu.refsSinceTick = 0
+	mmu.size = 0
+	if mmu.tickPeriod <= 0 {
+		mmu.tickPeriod = 5
+	}
+}
+
+// tick right-shifts every resident frame's counter and ORs in its reference
+// bit as the new high bit, then clears the reference bit for the next period.
+func (mmu *Aging) tick() {
+	var i int64
+	for i = 0; i < numFrames; i++ {
+		var highBit uint8 = 0
+		if mmu.refBit[i] {
+			highBit = 1 << 7
+		}
+		mmu.counter[i] = (mmu.counter[i] >> 1) | highBit
+		mmu.refBit[i] = false
+	}
+}
+
+// getReplaceFrameIndex picks the frame with the smallest counter, ties broken
+// by the lower frame index.
+func (mmu *Aging) getReplaceFrameIndex() int64 {
+	var minIndex int64 = 0
+	minCounter := mmu.counter[0]
+	var i int64
+	for i = 1; i < numFrames; i++ {
+		if mmu.counter[i] < minCounter {
+			minCounter = mmu.counter[i]
+			minIndex = i
+		}
+	}
+	return minIndex
+}
+
+func (mmu *Aging) Access(pageNum int64, offset int64, isWrite bool) {
+	var frameIndex int64
+
+	pfn := data.pageTable[pageNum].pfn
+	if pfn < 0 { // page is currently not in a frame, put it there
+
+		// frame is full, steal a page.
+		if mmu.size == numFrames {
+			frameIndex = mmu.getReplaceFrameIndex()
+
+			replace_pageNum := data.pageFrames[frameIndex] // virtual page number to be replaced
+			evictPage(replace_pageNum)
+			mmu.counter[frameIndex] = 0
+			mmu.refBit[frameIndex] = false
+		} else { // mmu.size < numFrames, we still have space in the frame, use it instead of stealing pages
+			frameIndex = mmu.size
+			mmu.size++
+		}
+
+		loadPage(pageNum, pfn)
+
+		data.pageTable[pageNum].pfn = frameIndex
+		data.pageTable[pageNum].firstUsed = cnt
+		if isWrite {
+			data.pageTable[pageNum].dirty = true
+			invalidateSwap(pageNum)
+		}
+
+		data.pageFrames[frameIndex] = pageNum
+		mmu.refBit[frameIndex] = true
+		data.numMissed++
+	} else { // page is currently in a frame, just set its reference bit
+		if isWrite {
+			data.pageTable[pageNum].dirty = true
+		}
+		mmu.refBit[pfn] = true
+	}
+
+	mmu.refsSinceTick++
+	if mmu.refsSinceTick >= mmu.tickPeriod {
+		mmu.tick()
+		mmu.refsSinceTick = 0
+	}
+
+	data.pageTable[pageNum].lastUsed = cnt
+	data.numReferenced++
+}
+
+func (mmu *WSClock) Init() {
+	mmu.ref = make([]bool, numFrames)
+	mmu.lastUsed = make([]int64, numFrames)
+	mmu.hand = 0
+	mmu.size = 0
+	if mmu.tau <= 0 {
+		mmu.tau = 10000
+	}
+}
+
+// findVictim sweeps frames circularly from the hand, clearing reference bits
+// and refreshing lastUsed along the way, and returns the frame to steal.
+func (mmu *WSClock) findVictim() int64 {
+	var oldestDirtyIndex int64 = -1
+	var oldestDirtyTime int64 = (1 << 63) - 1
+	start := mmu.hand
+
+	for {
+		idx := mmu.hand
+		pageNum := data.pageFrames[idx]
+
+		if mmu.ref[idx] {
+			mmu.ref[idx] = false
+			mmu.lastUsed[idx] = cnt
+		} else if cnt-mmu.lastUsed[idx] > mmu.tau {
+			if !data.pageTable[pageNum].dirty {
+				mmu.hand = (idx + 1) % numFrames
+				return idx
+			}
+			// schedule swap-out, keep sweeping for a clean candidate
+			writeBack(pageNum)
+			if mmu.lastUsed[idx] < oldestDirtyTime {
+				oldestDirtyTime = mmu.lastUsed[idx]
+				oldestDirtyIndex = idx
+			}
+		}
+
+		mmu.hand = (idx + 1) % numFrames
+		if mmu.hand == start {
+			break
+		}
+	}
+
+	if oldestDirtyIndex != -1 {
+		mmu.hand = (oldestDirtyIndex + 1) % numFrames
+		return oldestDirtyIndex
+	}
+
+	// full revolution found no out-of-window candidate at all; fall back to
+	// stealing whatever frame the hand currently sits on
+	victim := mmu.hand
+	mmu.hand = (mmu.hand + 1) % numFrames
+	return victim
+}
+
+func (mmu *WSClock) Access(pageNum int64, offset int64, isWrite bool) {
+	var frameIndex int64
+
+	pfn := data.pageTable[pageNum].pfn
+	if pfn < 0 { // page is currently not in a frame, put it there
+
+		// frame is full, steal a page.
+		if mmu.size == numFrames {
+			frameIndex = mmu.findVictim()
+
+			replace_pageNum := data.pageFrames[frameIndex] // virtual page number to be replaced
+			evictPage(replace_pageNum)
+		} else { // mmu.size < numFrames, we still have space in the frame, use it instead of stealing pages
+			frameIndex = mmu.size
+			mmu.size++
+		}
+
+		loadPage(pageNum, pfn)
+
+		data.pageTable[pageNum].pfn = frameIndex
+		data.pageTable[pageNum].firstUsed = cnt
+		if isWrite {
+			data.pageTable[pageNum].dirty = true
+			invalidateSwap(pageNum)
+		}
+
+		data.pageFrames[frameIndex] = pageNum
+		mmu.ref[frameIndex] = true
+		mmu.lastUsed[frameIndex] = cnt
+		data.numMissed++
+	} else { // page is currently in a frame, just set its reference bit
+		if isWrite {
+			data.pageTable[pageNum].dirty = true
+		}
+		mmu.ref[pfn] = true
+	}
+	data.pageTable[pageNum].lastUsed = cnt
+	data.numReferenced++
+}
+
 func (mmu *LRU) Init() {
 	mmu.nodeTable = make([]*LRUEntry, numPages)
 	mmu.head = nil
@@ -189,14 +549,7 @@ func (mmu *LRU) Access(pageNum int64, offset int64, isWrite bool) {
 				fmt.Printf("IN LRU WRITE, pageNum: %v\n, pfn: %v, tail page pfn: %v\n", pageNum, pfn, mmu.tail.my_page.pfn)
 			}
 			replace_pageNum := data.pageFrames[frameIndex] // virtual page number to be replaced
-			if data.pageTable[replace_pageNum].dirty {     // if the page to be replaced is dirty, write it to swap
-				data.pageTable[replace_pageNum].dirty = false
-				data.pageTable[replace_pageNum].onDisk = true
-				data.numWrittenToSwap++
-			}
-			data.pageTable[replace_pageNum].inSwap = true
-			data.pageTable[replace_pageNum].pfn = -1
-			data.numStolen++
+			evictPage(replace_pageNum)
 			mmu.nodeTable[replace_pageNum] = nil
 
 			// unlink tail and add new entry to front
@@ -237,20 +590,11 @@ func (mmu *LRU) Access(pageNum int64, offset int64, isWrite bool) {
 		}
 
 		// update metadata
-		if pfn == -2 { // page to write has never been mapped before
-			data.numMapped++
-		} else {
-			if data.pageTable[pageNum].inSwap { // check if it's in swap
-				if data.pageTable[pageNum].onDisk { // only true if this page has been written to in the past and was evicted after
-					data.numRecoveredFromSwapped++
-				}
-				data.pageTable[pageNum].inSwap = false
-			}
-		}
+		loadPage(pageNum, pfn)
 
 		if isWrite {
 			data.pageTable[pageNum].dirty = true
-			data.pageTable[pageNum].onDisk = false
+			invalidateSwap(pageNum)
 		}
 
 		data.pageTable[pageNum].firstUsed = cnt
@@ -307,6 +651,7 @@ func (mmu *OPTIMAL) Init() {
 	mmu.refList = make([]*RefList, numPages)
 	mmu.head = nil
 	mmu.tail = nil
+	mmu.initHeap()
 	cnt = 1
 	for {
 		inp, err := reader.ReadString('\n')
@@ -414,58 +759,106 @@ func (mmu *OPTIMAL) Init() {
 	printMetadata()
 }
 
-// newPageTime is the next use time of the page to be newly inserted into a frame
-// this function sets maxFrameIndex to newPageFrameIndex if newPageTime is greater than maxFrameTime
-// // if times are equal, set the one with the smaller index
-// func (mmu *OPTIMAL) CheckReplaceFrameIndex(newPageTime int64, newPageFrameIndex int64) {
-// 	if newPageTime > mmu.maxFrameTime {
-// 		mmu.nxtMaxFrameIndex = mmu.maxFrameIndex
-// 		mmu.nxtMaxFrameTime = mmu.maxFrameTime
-
-// 		mmu.maxFrameIndex = newPageFrameIndex
-// 		mmu.maxFrameTime = newPageTime
-
-// 	} else if newPageTime == mmu.maxFrameTime {
-// 		if newPageFrameIndex < mmu.maxFrameIndex {
-// 			mmu.nxtMaxFrameIndex = mmu.maxFrameIndex
-// 			mmu.nxtMaxFrameTime = mmu.maxFrameTime
-
-// 			mmu.maxFrameIndex = newPageFrameIndex
-// 			mmu.maxFrameTime = newPageTime
-// 		}
-// 	} else {
-// 		if newPageTime > mmu.nxtMaxFrameTime {
-// 			mmu.nxtMaxFrameIndex = newPageFrameIndex
-// 			mmu.nxtMaxFrameTime = newPageTime
-
-// 		} else if newPageTime == mmu.nxtMaxFrameTime {
-// 			if newPageFrameIndex < mmu.nxtMaxFrameIndex {
-// 				mmu.nxtMaxFrameIndex = newPageFrameIndex
-// 				mmu.nxtMaxFrameTime = newPageTime
-// 			}
-// 		}
-// 	}
-// }
+// initHeap (re)sets up an empty indexed max-heap sized for numFrames
+// resident frames, so OPTIMAL can be primed either from Init()'s own file
+// read or from an in-memory op slice via buildRefLists.
+func (mmu *OPTIMAL) initHeap() {
+	mmu.heap = make([]heapEntry, numFrames)
+	mmu.heapPos = make([]int64, numFrames)
+	var fi int64
+	for fi = 0; fi < numFrames; fi++ {
+		mmu.heapPos[fi] = -1
+	}
+	mmu.heapSize = 0
+}
 
-func (mmu *OPTIMAL) getReplaceFrameIndex() int64 {
-	var max_time int64 = 0
-	var max_index int64 = 0
-	for i := int64(0); i < numFrames; i++ {
-		pageNum := data.pageFrames[i]
-		var cur_time int64
+// nextUseTime returns the reference time at the head of pageNum's refList
+// (its next use once Access has advanced the head past the current
+// reference), or the sentinel MaxInt64 if it's never referenced again.
+func (mmu *OPTIMAL) nextUseTime(pageNum int64) int64 {
+	if mmu.refList[pageNum].head == nil {
+		return (1 << 63) - 1
+	}
+	return mmu.refList[pageNum].head.timeRef
+}
 
-		if mmu.refList[pageNum].head == nil {
-			cur_time = (1 << 63) - 1
-		} else {
-			cur_time = mmu.refList[pageNum].head.timeRef
+func heapEntryGreater(a, b heapEntry) bool {
+	if a.time != b.time {
+		return a.time > b.time
+	}
+	return a.frameIndex < b.frameIndex
+}
+
+func (mmu *OPTIMAL) heapSwap(i, j int64) {
+	mmu.heap[i], mmu.heap[j] = mmu.heap[j], mmu.heap[i]
+	mmu.heapPos[mmu.heap[i].frameIndex] = i
+	mmu.heapPos[mmu.heap[j].frameIndex] = j
+}
+
+func (mmu *OPTIMAL) siftUp(i int64) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !heapEntryGreater(mmu.heap[i], mmu.heap[parent]) {
+			break
 		}
+		mmu.heapSwap(i, parent)
+		i = parent
+	}
+}
 
-		if cur_time > max_time {
-			max_time = cur_time
-			max_index = i
+func (mmu *OPTIMAL) siftDown(i int64) {
+	for {
+		left, right := 2*i+1, 2*i+2
+		largest := i
+		if left < mmu.heapSize && heapEntryGreater(mmu.heap[left], mmu.heap[largest]) {
+			largest = left
+		}
+		if right < mmu.heapSize && heapEntryGreater(mmu.heap[right], mmu.heap[largest]) {
+			largest = right
+		}
+		if largest == i {
+			break
 		}
+		mmu.heapSwap(i, largest)
+		i = largest
 	}
-	return max_index
+}
+
+// heapPush inserts a newly-resident frame's next-use time in O(log F).
+func (mmu *OPTIMAL) heapPush(t int64, frameIndex int64) {
+	idx := mmu.heapSize
+	mmu.heap[idx] = heapEntry{time: t, frameIndex: frameIndex}
+	mmu.heapPos[frameIndex] = idx
+	mmu.heapSize++
+	mmu.siftUp(idx)
+}
+
+// heapUpdate re-keys a resident frame's next-use time in O(log F), e.g. after
+// its page is referenced again and its refList head advances.
+func (mmu *OPTIMAL) heapUpdate(frameIndex int64, newTime int64) {
+	idx := mmu.heapPos[frameIndex]
+	old := mmu.heap[idx].time
+	mmu.heap[idx].time = newTime
+	if newTime > old {
+		mmu.siftUp(idx)
+	} else if newTime < old {
+		mmu.siftDown(idx)
+	}
+}
+
+// getReplaceFrameIndex pops and returns the frame whose resident page will
+// be used furthest in the future (ties broken toward the smaller frame
+// index), in O(log F).
+func (mmu *OPTIMAL) getReplaceFrameIndex() int64 {
+	root := mmu.heap[0]
+	last := mmu.heapSize - 1
+	mmu.heapSwap(0, last)
+	mmu.heapPos[root.frameIndex] = -1
+	mmu.heapSize--
+	if mmu.heapSize > 0 {
+		mmu.siftDown(0)
+	}
+	return root.frameIndex
 }
 
 func (mmu *OPTIMAL) Access(pageNum int64, offset int64, isWrite bool) {
@@ -476,39 +869,22 @@ func (mmu *OPTIMAL) Access(pageNum int64, offset int64, isWrite bool) {
 
 		// frame is full, steal a page.
 		if mmu.size == numFrames {
-			//frameIndex = mmu.maxFrameIndex
 			frameIndex = mmu.getReplaceFrameIndex()
 
 			replace_pageNum := data.pageFrames[frameIndex] // virtual page number to be replaced
-			if data.pageTable[replace_pageNum].dirty {     // if the page to be replaced is dirty, write it to swap
-				data.pageTable[replace_pageNum].dirty = false
-				data.pageTable[replace_pageNum].onDisk = true
-				data.numWrittenToSwap++
-			}
-			data.pageTable[replace_pageNum].inSwap = true
-			data.pageTable[replace_pageNum].pfn = -1
-			data.numStolen++
+			evictPage(replace_pageNum)
 		} else { // mmu.size < numFrames, we still have space in the frame, use it instead of stealing pages
 			frameIndex = mmu.size
 			mmu.size++
 		}
 
-		if pfn == -2 { // page to write has never been mapped before
-			data.numMapped++
-		} else {
-			if data.pageTable[pageNum].inSwap { // check if it's in swap
-				if data.pageTable[pageNum].onDisk { // only true if this page has been written to in the past
-					data.numRecoveredFromSwapped++
-				}
-				data.pageTable[pageNum].inSwap = false
-			}
-		}
+		loadPage(pageNum, pfn)
 
 		data.pageTable[pageNum].pfn = frameIndex
 		data.pageTable[pageNum].firstUsed = cnt
 		if isWrite {
 			data.pageTable[pageNum].dirty = true
-			data.pageTable[pageNum].onDisk = false
+			invalidateSwap(pageNum)
 		}
 
 		data.pageFrames[frameIndex] = pageNum
@@ -520,11 +896,12 @@ func (mmu *OPTIMAL) Access(pageNum int64, offset int64, isWrite bool) {
 		}
 	}
 	mmu.refList[pageNum].head = mmu.refList[pageNum].head.nxt // move the reference list of the current page by 1 to update the next use time
-	// if mmu.refList[pageNum].head == nil {                     // this is the last time page pageNum will be referenced
-	// 	mmu.CheckReplaceFrameIndex((1<<63)-1, frameIndex)
-	// } else {
-	// 	mmu.CheckReplaceFrameIndex(mmu.refList[pageNum].head.timeRef, frameIndex)
-	// }
+	newTime := mmu.nextUseTime(pageNum)
+	if pfn < 0 {
+		mmu.heapPush(newTime, frameIndex) // frame had no heap entry: either brand new or just popped for eviction
+	} else {
+		mmu.heapUpdate(pfn, newTime)
+	}
 	data.pageTable[pageNum].lastUsed = cnt
 	data.numReferenced++
 }
@@ -584,7 +961,7 @@ func printMetadata() {
 				s.WriteString(fmt.Sprintf(" framenum:%v", data.pageTable[i].pfn))
 			}
 			s.WriteString(" ondisk:")
-			if data.pageTable[i].onDisk {
+			if data.pageTable[i].swapSlot >= 0 {
 				s.WriteString("1")
 			} else {
 				s.WriteString("0")
@@ -628,17 +1005,50 @@ func printMetadata() {
 	fmt.Printf("Frame stolen instances: %v\n", data.numStolen)
 	fmt.Printf("Stolen frames written to swapspace: %v\n", data.numWrittenToSwap)
 	fmt.Printf("Stolen frames recovered from swapspace: %v\n", data.numRecoveredFromSwapped)
+	fmt.Printf("Pages stored in frontswap: %v\n", data.numFrontswapStored)
+	fmt.Printf("Frontswap hits: %v\n", data.numFrontswapHits)
 }
 
 var mmu MMU
 
 func main() {
 	argsWithoutProg := os.Args[1:]
-	if argsWithoutProg[0] == "-w" {
-		useBackingBlocks = true
+	var agingTick int64 = 5
+	var tau int64 = 10000
+	var storePath string
+	var frontswapMB int64
+	var policiesFlag string
+	var format string = "table"
+	for len(argsWithoutProg) > 0 && strings.HasPrefix(argsWithoutProg[0], "-") {
+		switch {
+		case argsWithoutProg[0] == "-w":
+			useBackingBlocks = true
+		case strings.HasPrefix(argsWithoutProg[0], "-tick="):
+			parseFirstLine(&agingTick, strings.TrimPrefix(argsWithoutProg[0], "-tick="))
+		case strings.HasPrefix(argsWithoutProg[0], "-tau="):
+			parseFirstLine(&tau, strings.TrimPrefix(argsWithoutProg[0], "-tau="))
+		case strings.HasPrefix(argsWithoutProg[0], "-store="):
+			storePath = strings.TrimPrefix(argsWithoutProg[0], "-store=")
+		case strings.HasPrefix(argsWithoutProg[0], "-frontswap="):
+			parseFirstLine(&frontswapMB, strings.TrimPrefix(argsWithoutProg[0], "-frontswap="))
+		case strings.HasPrefix(argsWithoutProg[0], "-policies="):
+			policiesFlag = strings.TrimPrefix(argsWithoutProg[0], "-policies=")
+		case strings.HasPrefix(argsWithoutProg[0], "-format="):
+			format = strings.TrimPrefix(argsWithoutProg[0], "-format=")
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown flag: %v\n", argsWithoutProg[0])
+			return
+		}
 		argsWithoutProg = argsWithoutProg[1:]
 	}
-	file, ok := os.Open(argsWithoutProg[1])
+
+	// in single-policy mode the algorithm name is a leading positional
+	// argument before the trace file; -policies replaces it
+	inputFileIdx := 1
+	if policiesFlag != "" {
+		inputFileIdx = 0
+	}
+	file, ok := os.Open(argsWithoutProg[inputFileIdx])
 	if ok != nil {
 		fmt.Fprintln(os.Stderr, "Failed to open input file...")
 		return
@@ -669,26 +1079,51 @@ func main() {
 	fmt.Printf("Num pages: %v\n", numPages)
 	fmt.Printf("Num backing blocks: %v\n", numBackingBlocks)
 
-	pageTable := make([]*page, numPages)
-	var i int64
-	for i = 0; i < numPages; i++ {
-		pageTable[i] = &page{pfn: -2, dirty: false, inSwap: false, onDisk: false}
+	if storePath != "" {
+		var err error
+		backing, err = newBackingStore(storePath, pageSize, numPages, numBackingBlocks)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open backing store %v: %v\n", storePath, err)
+			return
+		}
+		defer backing.Close()
 	}
-	pageFrames := make([]int64, numFrames)
-	for i = 0; i < numFrames; i++ {
-		pageFrames[i] = -1
+
+	if frontswapMB > 0 {
+		frontswapTier = newFrontswap(frontswapMB * 1024 * 1024)
 	}
-	data = page_metadata{pageTable: pageTable, pageFrames: pageFrames}
 
-	if argsWithoutProg[0] == "FIFO" {
-		mmu = &FIFO{nxt_idx: make(chan int64, numFrames)}
-		fmt.Printf("Reclaim algorithm: FIFO\n")
-	} else if argsWithoutProg[0] == "LRU" {
-		mmu = &LRU{head: nil, tail: nil, size: 0}
-		fmt.Printf("Reclaim algorithm: LRU\n")
-	} else {
-		mmu = &OPTIMAL{}
-		fmt.Printf("Reclaim algorithm: OPTIMAL\n")
+	if policiesFlag != "" {
+		ops, err := parseTrace(reader)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return
+		}
+		names := strings.Split(policiesFlag, ",")
+		results := make([]policyMetrics, 0, len(names))
+		for _, name := range names {
+			metrics, err := runPolicyOverOps(name, ops, agingTick, tau)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid -policies= list: %v\n", err)
+				return
+			}
+			results = append(results, metrics)
+		}
+		printReport(results, format)
+		return
+	}
+
+	data = freshPageMetadata()
+
+	var label string
+	var mmuErr error
+	mmu, label, mmuErr = newMMU(argsWithoutProg[0], agingTick, tau)
+	if mmuErr != nil {
+		fmt.Fprintln(os.Stderr, mmuErr)
+		return
+	}
+	fmt.Printf("Reclaim algorithm: %v\n", label)
+	if label == "OPTIMAL" {
 		mmu.Init() // will read in the page references, do the preprocessing for OPT, then process the references
 		return
 	}
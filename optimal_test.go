@@ -0,0 +1,59 @@
+package main
+
+import "testing"
+
+// TestOptimalHeapEvictsFurthestNextUse exercises the indexed max-heap
+// directly: push frames with varying next-use times, including a tie and
+// the "never referenced again" sentinel, and confirm getReplaceFrameIndex
+// pops them in furthest-next-use order with the smaller frame index
+// winning ties, matching the pre-heap O(n*frames) scan it replaced.
+func TestOptimalHeapEvictsFurthestNextUse(t *testing.T) {
+	numFrames = 4
+	mmu := &OPTIMAL{}
+	mmu.initHeap()
+
+	var neverUsed int64 = (1 << 63) - 1
+	mmu.heapPush(10, 0)
+	mmu.heapPush(30, 1)
+	mmu.heapPush(30, 2) // ties with frame 1; frame 1 should win (smaller index)
+	mmu.heapPush(neverUsed, 3)
+
+	if got := mmu.getReplaceFrameIndex(); got != 3 {
+		t.Fatalf("1st eviction = frame %v, want 3 (never referenced again)", got)
+	}
+	if got := mmu.getReplaceFrameIndex(); got != 1 {
+		t.Fatalf("2nd eviction = frame %v, want 1 (tie broken toward smaller index)", got)
+	}
+	if got := mmu.getReplaceFrameIndex(); got != 2 {
+		t.Fatalf("3rd eviction = frame %v, want 2", got)
+	}
+	if got := mmu.getReplaceFrameIndex(); got != 0 {
+		t.Fatalf("4th eviction = frame %v, want 0 (smallest next-use time left)", got)
+	}
+}
+
+// TestOptimalHeapUpdateReorders confirms heapUpdate re-keys a resident
+// frame in place, changing who the next eviction targets.
+func TestOptimalHeapUpdateReorders(t *testing.T) {
+	numFrames = 3
+	mmu := &OPTIMAL{}
+	mmu.initHeap()
+
+	mmu.heapPush(5, 0)
+	mmu.heapPush(20, 1)
+	mmu.heapPush(15, 2)
+
+	// frame 1 currently has the furthest next-use time (20); re-key it
+	// sooner than frame 2, so frame 2 becomes the victim instead.
+	mmu.heapUpdate(1, 1)
+
+	if got := mmu.getReplaceFrameIndex(); got != 2 {
+		t.Fatalf("eviction after heapUpdate = frame %v, want 2", got)
+	}
+	if got := mmu.getReplaceFrameIndex(); got != 0 {
+		t.Fatalf("next eviction = frame %v, want 0", got)
+	}
+	if got := mmu.getReplaceFrameIndex(); got != 1 {
+		t.Fatalf("last eviction = frame %v, want 1 (re-keyed to smallest)", got)
+	}
+}